@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"math"
+)
+
+// bloomMagic identifies the bloomExporter output format: a fixed header
+// followed by a packed bit array, sized for the requested false-positive
+// rate.
+const bloomMagic = "FNDRBLM1"
+
+// bloomExporter writes a Bloom filter containing every path in the
+// database, so a downstream tool can test path membership without loading
+// the full database.
+type bloomExporter struct {
+	targetFPR float64
+}
+
+// bloomParams picks a bit-array size and hash count for n items at the
+// given false-positive rate, using the standard optimal-Bloom-filter
+// formulas.
+func bloomParams(n int, fpr float64) (bits uint64, hashes uint64) {
+	if n < 1 {
+		n = 1
+	}
+	if fpr <= 0 || fpr >= 1 {
+		fpr = 0.01
+	}
+	m := math.Ceil(-1 * float64(n) * math.Log(fpr) / (math.Ln2 * math.Ln2))
+	k := math.Round((m / float64(n)) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+	return uint64(m), uint64(k)
+}
+
+// bloomHashes returns the two independent 64-bit hashes of s used to derive
+// the k probe positions via double hashing (h1 + i*h2).
+func bloomHashes(s string) (h1, h2 uint64) {
+	a := fnv.New64a()
+	a.Write([]byte(s))
+	h1 = a.Sum64()
+
+	b := fnv.New64()
+	b.Write([]byte(s))
+	h2 = b.Sum64()
+	return h1, h2
+}
+
+func setBit(bits []byte, i uint64) {
+	bits[i/8] |= 1 << (i % 8)
+}
+
+func (e bloomExporter) Export(w io.Writer, metadata *cacheMetadata, entries []CacheEntry) error {
+	sortedPaths, _, _ := collectSortedPaths(entries)
+
+	numBits, numHashes := bloomParams(len(sortedPaths), e.targetFPR)
+	bitArray := make([]byte, (numBits+7)/8)
+
+	for _, path := range sortedPaths {
+		h1, h2 := bloomHashes(path)
+		for i := uint64(0); i < numHashes; i++ {
+			setBit(bitArray, (h1+i*h2)%numBits)
+		}
+	}
+
+	if _, err := io.WriteString(w, bloomMagic); err != nil {
+		return err
+	}
+	header := make([]byte, 8*3)
+	binary.LittleEndian.PutUint64(header[0:8], uint64(len(sortedPaths)))
+	binary.LittleEndian.PutUint64(header[8:16], numBits)
+	binary.LittleEndian.PutUint64(header[16:24], numHashes)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(bitArray)
+	return err
+}
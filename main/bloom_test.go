@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+func TestBloomParamsSanity(t *testing.T) {
+	cases := []struct {
+		n   int
+		fpr float64
+	}{
+		{0, 0.01},
+		{1, 0.01},
+		{1000, 0.01},
+		{1000, 2}, // out-of-range fpr should fall back to a default
+	}
+	for _, c := range cases {
+		bits, hashes := bloomParams(c.n, c.fpr)
+		if bits == 0 {
+			t.Errorf("bloomParams(%d, %v): bits = 0, want > 0", c.n, c.fpr)
+		}
+		if hashes < 1 {
+			t.Errorf("bloomParams(%d, %v): hashes = %d, want >= 1", c.n, c.fpr, hashes)
+		}
+	}
+}
+
+// bloomQuery reports whether path's bits are all set in bitArray, mirroring
+// the probe sequence bloomExporter.Export uses to set them.
+func bloomQuery(bitArray []byte, numBits, numHashes uint64, path string) bool {
+	h1, h2 := bloomHashes(path)
+	for i := uint64(0); i < numHashes; i++ {
+		bitIndex := (h1 + i*h2) % numBits
+		if bitArray[bitIndex/8]&(1<<(bitIndex%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBloomExportNoFalseNegatives(t *testing.T) {
+	entries := []CacheEntry{CacheEntry{{
+		Root: "/root0",
+		Dirs: []PersistedDirInfo{
+			{P: "a", T: 1, I: 1, F: []string{"x.bp", "y.bp"}},
+			{P: "a/b", T: 1, I: 2, F: []string{"z.bp"}},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	if err := (bloomExporter{targetFPR: 0.01}).Export(&buf, &cacheMetadata{}, entries); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	data := buf.Bytes()
+	if string(data[:len(bloomMagic)]) != bloomMagic {
+		t.Fatalf("missing bloom magic header")
+	}
+	header := data[len(bloomMagic) : len(bloomMagic)+24]
+	numItems := binary.LittleEndian.Uint64(header[0:8])
+	numBits := binary.LittleEndian.Uint64(header[8:16])
+	numHashes := binary.LittleEndian.Uint64(header[16:24])
+	bitArray := data[len(bloomMagic)+24:]
+
+	sortedPaths, _, _ := collectSortedPaths(entries)
+	if uint64(len(sortedPaths)) != numItems {
+		t.Fatalf("header claims %d items, collectSortedPaths found %d", numItems, len(sortedPaths))
+	}
+
+	for _, path := range sortedPaths {
+		if !bloomQuery(bitArray, numBits, numHashes, path) {
+			t.Errorf("false negative: inserted path %q not found in bloom filter", path)
+		}
+	}
+
+	falsePositives := 0
+	const probes = 2000
+	for i := 0; i < probes; i++ {
+		if bloomQuery(bitArray, numBits, numHashes, fmt.Sprintf("/definitely/not/present/%d", i)) {
+			falsePositives++
+		}
+	}
+	// The filter was sized for a 1% false-positive rate; allow generous
+	// headroom since this is a sanity check, not a statistical proof.
+	if rate := float64(falsePositives) / probes; rate > 0.1 {
+		t.Errorf("false-positive rate %.3f exceeds sanity bound for a 0.01 target", rate)
+	}
+}
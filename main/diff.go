@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// dirDiff describes how a single directory changed between two database
+// snapshots.
+type dirDiff struct {
+	Path         string   `json:"Path"`
+	Status       string   `json:"Status"` // "added", "removed", or "modified"
+	OldMTime     int64    `json:"OldMTime,omitempty"`
+	NewMTime     int64    `json:"NewMTime,omitempty"`
+	OldInode     uint64   `json:"OldInode,omitempty"`
+	NewInode     uint64   `json:"NewInode,omitempty"`
+	AddedFiles   []string `json:"AddedFiles,omitempty"`
+	RemovedFiles []string `json:"RemovedFiles,omitempty"`
+}
+
+// diffDatabases compares the directories indexed by oldEntries and
+// newEntries and returns one dirDiff per path that was added, removed, or
+// whose PersistedDirInfo changed, sorted by path.
+func diffDatabases(oldEntries, newEntries []CacheEntry) []dirDiff {
+	oldIdx := buildPathIndex(oldEntries)
+	newIdx := buildPathIndex(newEntries)
+
+	oldDirs := make(map[string]*PersistedDirInfo)
+	oldIdx.walk(func(path string, node *dirNode) { oldDirs[path] = node.info })
+
+	newDirs := make(map[string]*PersistedDirInfo)
+	newIdx.walk(func(path string, node *dirNode) { newDirs[path] = node.info })
+
+	var diffs []dirDiff
+	for path, newInfo := range newDirs {
+		oldInfo, existed := oldDirs[path]
+		if !existed {
+			diffs = append(diffs, dirDiff{Path: path, Status: "added"})
+			continue
+		}
+		if d, changed := compareDirInfo(path, oldInfo, newInfo); changed {
+			diffs = append(diffs, d)
+		}
+	}
+	for path := range oldDirs {
+		if _, stillPresent := newDirs[path]; !stillPresent {
+			diffs = append(diffs, dirDiff{Path: path, Status: "removed"})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+func compareDirInfo(path string, oldInfo, newInfo *PersistedDirInfo) (dirDiff, bool) {
+	added, removed := fileSetDiff(oldInfo.F, newInfo.F)
+	if oldInfo.T == newInfo.T && oldInfo.I == newInfo.I && len(added) == 0 && len(removed) == 0 {
+		return dirDiff{}, false
+	}
+	return dirDiff{
+		Path:         path,
+		Status:       "modified",
+		OldMTime:     oldInfo.T,
+		NewMTime:     newInfo.T,
+		OldInode:     oldInfo.I,
+		NewInode:     newInfo.I,
+		AddedFiles:   added,
+		RemovedFiles: removed,
+	}, true
+}
+
+// fileSetDiff reports which filenames in newFiles are not in oldFiles
+// (added) and which filenames in oldFiles are not in newFiles (removed).
+func fileSetDiff(oldFiles, newFiles []string) (added, removed []string) {
+	oldSet := make(map[string]bool, len(oldFiles))
+	for _, f := range oldFiles {
+		oldSet[f] = true
+	}
+	newSet := make(map[string]bool, len(newFiles))
+	for _, f := range newFiles {
+		newSet[f] = true
+	}
+	for _, f := range newFiles {
+		if !oldSet[f] {
+			added = append(added, f)
+		}
+	}
+	for _, f := range oldFiles {
+		if !newSet[f] {
+			removed = append(removed, f)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// printDiff writes the diff in either human-readable or JSON form. When
+// nameOnly is set, only the changed paths are printed, one per line,
+// regardless of format.
+func printDiff(diffs []dirDiff, jsonFormat bool, nameOnly bool) {
+	if nameOnly {
+		for _, d := range diffs {
+			fmt.Println(d.Path)
+		}
+		return
+	}
+
+	if jsonFormat {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diffs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding diff JSON: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	for _, d := range diffs {
+		switch d.Status {
+		case "added":
+			fmt.Printf("+ %s\n", d.Path)
+		case "removed":
+			fmt.Printf("- %s\n", d.Path)
+		case "modified":
+			fmt.Printf("~ %s\n", d.Path)
+			if d.OldMTime != d.NewMTime {
+				fmt.Printf("    mtime: %d -> %d\n", d.OldMTime, d.NewMTime)
+			}
+			if d.OldInode != d.NewInode {
+				fmt.Printf("    inode: %d -> %d\n", d.OldInode, d.NewInode)
+			}
+			for _, f := range d.AddedFiles {
+				fmt.Printf("    + %s\n", f)
+			}
+			for _, f := range d.RemovedFiles {
+				fmt.Printf("    - %s\n", f)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFileSetDiff(t *testing.T) {
+	cases := []struct {
+		name                   string
+		oldFiles, newFiles     []string
+		wantAdded, wantRemoved []string
+	}{
+		{"no change", []string{"a", "b"}, []string{"a", "b"}, nil, nil},
+		{"added only", []string{"a"}, []string{"a", "b"}, []string{"b"}, nil},
+		{"removed only", []string{"a", "b"}, []string{"a"}, nil, []string{"b"}},
+		{"both", []string{"a", "b"}, []string{"b", "c"}, []string{"c"}, []string{"a"}},
+		{"empty to empty", nil, nil, nil, nil},
+	}
+	for _, c := range cases {
+		added, removed := fileSetDiff(c.oldFiles, c.newFiles)
+		sort.Strings(added)
+		sort.Strings(removed)
+		if !reflect.DeepEqual(added, c.wantAdded) {
+			t.Errorf("%s: added = %v, want %v", c.name, added, c.wantAdded)
+		}
+		if !reflect.DeepEqual(removed, c.wantRemoved) {
+			t.Errorf("%s: removed = %v, want %v", c.name, removed, c.wantRemoved)
+		}
+	}
+}
+
+func TestCompareDirInfoUnchanged(t *testing.T) {
+	oldInfo := &PersistedDirInfo{P: "/a", T: 1, I: 1, F: []string{"x"}}
+	newInfo := &PersistedDirInfo{P: "/a", T: 1, I: 1, F: []string{"x"}}
+	if _, changed := compareDirInfo("/a", oldInfo, newInfo); changed {
+		t.Fatal("expected no change when mtime, inode, and files are identical")
+	}
+}
+
+func TestCompareDirInfoModified(t *testing.T) {
+	oldInfo := &PersistedDirInfo{P: "/a", T: 1, I: 1, F: []string{"x"}}
+	newInfo := &PersistedDirInfo{P: "/a", T: 2, I: 1, F: []string{"x", "y"}}
+
+	d, changed := compareDirInfo("/a", oldInfo, newInfo)
+	if !changed {
+		t.Fatal("expected a change when mtime and file set differ")
+	}
+	if d.Status != "modified" || d.OldMTime != 1 || d.NewMTime != 2 {
+		t.Errorf("unexpected dirDiff: %+v", d)
+	}
+	if !reflect.DeepEqual(d.AddedFiles, []string{"y"}) {
+		t.Errorf("AddedFiles = %v, want [y]", d.AddedFiles)
+	}
+}
+
+func TestDiffDatabases(t *testing.T) {
+	oldEntries := []CacheEntry{CacheEntry{{
+		Root: "/root0",
+		Dirs: []PersistedDirInfo{
+			{P: "kept", T: 1, I: 1, F: []string{"a.bp"}},
+			{P: "removed", T: 1, I: 2, F: nil},
+		},
+	}}}
+	newEntries := []CacheEntry{CacheEntry{{
+		Root: "/root0",
+		Dirs: []PersistedDirInfo{
+			{P: "kept", T: 2, I: 1, F: []string{"a.bp"}},
+			{P: "added", T: 1, I: 3, F: nil},
+		},
+	}}}
+
+	diffs := diffDatabases(oldEntries, newEntries)
+	byPath := make(map[string]dirDiff)
+	for _, d := range diffs {
+		byPath[d.Path] = d
+	}
+
+	if len(diffs) != 3 {
+		t.Fatalf("expected 3 diffs (kept/modified, added, removed), got %d: %+v", len(diffs), diffs)
+	}
+	if byPath["/root0/kept"].Status != "modified" {
+		t.Errorf("expected /root0/kept to be modified, got %+v", byPath["/root0/kept"])
+	}
+	if byPath["/root0/added"].Status != "added" {
+		t.Errorf("expected /root0/added to be added, got %+v", byPath["/root0/added"])
+	}
+	if byPath["/root0/removed"].Status != "removed" {
+		t.Errorf("expected /root0/removed to be removed, got %+v", byPath["/root0/removed"])
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Exporter writes a loaded database out to w in some format. Implementations
+// must not assume they own w beyond the call; they should not close it.
+type Exporter interface {
+	Export(w io.Writer, metadata *cacheMetadata, entries []CacheEntry) error
+}
+
+// newExporter resolves a -format flag value to its Exporter.
+func newExporter(format string, bloomFPR float64) (Exporter, error) {
+	switch format {
+	case "", "text":
+		return textExporter{}, nil
+	case "json":
+		return jsonExporter{}, nil
+	case "ndjson":
+		return ndjsonExporter{}, nil
+	case "protobuf":
+		return protobufExporter{}, nil
+	case "bloom":
+		return bloomExporter{targetFPR: bloomFPR}, nil
+	default:
+		return nil, fmt.Errorf("unknown -format %q (want text, json, ndjson, protobuf, or bloom)", format)
+	}
+}
+
+// textExporter reproduces the layout of the tool's original human-readable
+// dump. Paths are listed in collectSortedPaths order, which is sorted
+// component by component rather than byte-wise over the whole path string.
+type textExporter struct{}
+
+func (textExporter) Export(w io.Writer, metadata *cacheMetadata, entries []CacheEntry) error {
+	fmt.Fprintf(w, "Database version: %s\n", metadata.Version)
+	fmt.Fprintf(w, "Config:\n")
+	fmt.Fprintf(w, "  WorkingDirectory: %s\n", metadata.Config.WorkingDirectory)
+	fmt.Fprintf(w, "  RootDirs: %v\n", metadata.Config.RootDirs)
+	fmt.Fprintf(w, "  FilesystemView: %s\n", metadata.Config.FilesystemView)
+	fmt.Fprintf(w, "  FollowSymlinks: %v\n", metadata.Config.FollowSymlinks)
+	fmt.Fprintf(w, "  ExcludeDirs: %v\n", metadata.Config.ExcludeDirs)
+	fmt.Fprintf(w, "  PruneFiles: %v\n", metadata.Config.PruneFiles)
+	fmt.Fprintf(w, "  IncludeFiles: %v\n", metadata.Config.IncludeFiles)
+	fmt.Fprintf(w, "  IncludeSuffixes: %v\n", metadata.Config.IncludeSuffixes)
+	fmt.Fprintln(w)
+
+	sortedPaths, entryCount, fileCount := collectSortedPaths(entries)
+
+	fmt.Fprintf(w, "Database contains %d directory entries with %d files\n", entryCount, fileCount)
+	fmt.Fprintf(w, "Total unique paths: %d\n\n", len(sortedPaths))
+	fmt.Fprintln(w, "All paths in database:")
+
+	for _, path := range sortedPaths {
+		fmt.Fprintln(w, path)
+	}
+	return nil
+}
+
+// jsonExporter dumps the full structured database: metadata plus every
+// PersistedDirs/PersistedDirInfo exactly as parsed, so no information is
+// lost compared to the on-disk format.
+type jsonExporter struct{}
+
+type jsonDatabase struct {
+	Metadata cacheMetadata `json:"Metadata"`
+	Entries  []CacheEntry  `json:"Entries"`
+}
+
+func (jsonExporter) Export(w io.Writer, metadata *cacheMetadata, entries []CacheEntry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonDatabase{Metadata: *metadata, Entries: entries})
+}
+
+// ndjsonExporter emits one JSON object per directory, each carrying its
+// resolved absolute path, so callers can stream the output into jq or
+// similar line-oriented tools without loading the whole database.
+type ndjsonExporter struct{}
+
+type ndjsonDir struct {
+	Path  string   `json:"Path"`
+	Root  string   `json:"Root"`
+	MTime int64    `json:"MTime"`
+	Inode uint64   `json:"Inode"`
+	Files []string `json:"Files"`
+}
+
+func (ndjsonExporter) Export(w io.Writer, metadata *cacheMetadata, entries []CacheEntry) error {
+	encoder := json.NewEncoder(w)
+	for _, cacheEntry := range entries {
+		for _, persistedDirs := range cacheEntry {
+			for _, dir := range persistedDirs.Dirs {
+				line := ndjsonDir{
+					Path:  cleanAbsPath(persistedDirs.Root, dir.P),
+					Root:  persistedDirs.Root,
+					MTime: dir.T,
+					Inode: dir.I,
+					Files: dir.F,
+				}
+				if err := encoder.Encode(line); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
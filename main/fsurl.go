@@ -0,0 +1,118 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// openDatabase resolves the -db argument to a readable stream. In addition
+// to plain local paths it understands a handful of URI schemes so CI
+// systems can point the tool straight at a build artifact without
+// extracting it first:
+//
+//	tar://archive.tar!path/inside   a file inside a local tar archive
+//	zip://archive.zip!path/inside   a file inside a local zip archive
+//	http://host/path                a file fetched over HTTP
+//	https://host/path               a file fetched over HTTPS
+func openDatabase(uri string) (io.ReadCloser, error) {
+	switch {
+	case strings.HasPrefix(uri, "tar://"):
+		return openTarEntry(strings.TrimPrefix(uri, "tar://"))
+	case strings.HasPrefix(uri, "zip://"):
+		return openZipEntry(strings.TrimPrefix(uri, "zip://"))
+	case strings.HasPrefix(uri, "http://"), strings.HasPrefix(uri, "https://"):
+		return openHTTP(uri)
+	default:
+		return os.Open(uri)
+	}
+}
+
+// splitArchiveURI splits "archive!inner/path" into its two halves.
+func splitArchiveURI(rest string) (archivePath string, innerPath string, err error) {
+	i := strings.IndexByte(rest, '!')
+	if i < 0 {
+		return "", "", fmt.Errorf("expected \"archive!path/inside\", got %q", rest)
+	}
+	return rest[:i], rest[i+1:], nil
+}
+
+func openTarEntry(rest string) (io.ReadCloser, error) {
+	archivePath, innerPath, err := splitArchiveURI(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening tar archive: %w", err)
+	}
+	defer file.Close()
+
+	tarReader := tar.NewReader(file)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%q not found in %s", innerPath, archivePath)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading tar archive: %w", err)
+		}
+		if header.Name != innerPath {
+			continue
+		}
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, fmt.Errorf("reading %q from tar archive: %w", innerPath, err)
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+func openZipEntry(rest string) (io.ReadCloser, error) {
+	archivePath, innerPath, err := splitArchiveURI(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening zip archive: %w", err)
+	}
+	defer zipReader.Close()
+
+	for _, f := range zipReader.File {
+		if f.Name != innerPath {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("opening %q in zip archive: %w", innerPath, err)
+		}
+		defer rc.Close()
+
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, fmt.Errorf("reading %q from zip archive: %w", innerPath, err)
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return nil, fmt.Errorf("%q not found in %s", innerPath, archivePath)
+}
+
+func openHTTP(uri string) (io.ReadCloser, error) {
+	resp, err := http.Get(uri)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", uri, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", uri, resp.Status)
+	}
+	return resp.Body, nil
+}
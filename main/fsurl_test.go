@@ -0,0 +1,150 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitArchiveURI(t *testing.T) {
+	cases := []struct {
+		rest        string
+		wantArchive string
+		wantInner   string
+		wantErr     bool
+	}{
+		{"out/db.tar!cache/db", "out/db.tar", "cache/db", false},
+		{"a.zip!b", "a.zip", "b", false},
+		{"no-bang-here", "", "", true},
+	}
+	for _, c := range cases {
+		archivePath, innerPath, err := splitArchiveURI(c.rest)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("splitArchiveURI(%q): expected error, got none", c.rest)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("splitArchiveURI(%q): unexpected error: %v", c.rest, err)
+			continue
+		}
+		if archivePath != c.wantArchive || innerPath != c.wantInner {
+			t.Errorf("splitArchiveURI(%q) = (%q, %q), want (%q, %q)", c.rest, archivePath, innerPath, c.wantArchive, c.wantInner)
+		}
+	}
+}
+
+func writeTestTar(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	defer tw.Close()
+	for name, contents := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(contents)), Mode: 0644}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func writeTestZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	defer zw.Close()
+	for name, contents := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOpenDatabaseDispatch(t *testing.T) {
+	dir := t.TempDir()
+
+	plainPath := filepath.Join(dir, "db.txt")
+	if err := os.WriteFile(plainPath, []byte("plain contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tarPath := filepath.Join(dir, "archive.tar")
+	writeTestTar(t, tarPath, map[string]string{"inner/db": "tar contents"})
+
+	zipPath := filepath.Join(dir, "archive.zip")
+	writeTestZip(t, zipPath, map[string]string{"inner/db": "zip contents"})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "http contents")
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name string
+		uri  string
+		want string
+	}{
+		{"plain path", plainPath, "plain contents"},
+		{"tar entry", "tar://" + tarPath + "!inner/db", "tar contents"},
+		{"zip entry", "zip://" + zipPath + "!inner/db", "zip contents"},
+		{"http", server.URL, "http contents"},
+	}
+	for _, c := range cases {
+		rc, err := openDatabase(c.uri)
+		if err != nil {
+			t.Errorf("%s: openDatabase(%q): %v", c.name, c.uri, err)
+			continue
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Errorf("%s: reading: %v", c.name, err)
+			continue
+		}
+		if string(data) != c.want {
+			t.Errorf("%s: contents = %q, want %q", c.name, data, c.want)
+		}
+	}
+}
+
+func TestOpenTarEntryMissing(t *testing.T) {
+	dir := t.TempDir()
+	tarPath := filepath.Join(dir, "archive.tar")
+	writeTestTar(t, tarPath, map[string]string{"inner/db": "contents"})
+
+	if _, err := openTarEntry(tarPath + "!missing"); err == nil {
+		t.Fatal("expected an error for a missing tar entry")
+	}
+}
+
+func TestOpenHTTPNon200(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := openHTTP(server.URL); err == nil {
+		t.Fatal("expected an error for a non-200 response")
+	}
+}
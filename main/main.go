@@ -60,43 +60,41 @@ func joinCleanPaths(base string, leaf string) string {
 	return base + "/" + leaf
 }
 
-func main() {
-	var dbPath string
-	flag.StringVar(&dbPath, "db", "", "path to database file (required)")
-	flag.Parse()
-
-	if dbPath == "" {
-		fmt.Fprintf(os.Stderr, "Error: -db flag is required\n")
-		flag.Usage()
-		os.Exit(1)
-	}
+// cleanAbsPath joins base and leaf, cleans the result, and makes sure it is
+// reported as an absolute path the way the rest of this tool expects.
+func cleanAbsPath(base string, leaf string) string {
+	path := filepath.Clean(joinCleanPaths(base, leaf))
+	if !filepath.IsAbs(path) {
+		path = "/" + path
+	}
+	return path
+}
 
-	file, err := os.Open(dbPath)
+// loadDatabaseFile opens dbPath and parses its version line, config line and
+// every cache entry that follows. The entries are returned in file order so
+// callers can either dump them directly or index them for interactive use.
+func loadDatabaseFile(dbPath string) (*cacheMetadata, []CacheEntry, error) {
+	stream, err := openDatabase(dbPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening database file: %v\n", err)
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("opening database file: %w", err)
 	}
-	defer file.Close()
+	defer stream.Close()
 
-	reader := bufio.NewReader(file)
+	reader := bufio.NewReader(stream)
 
 	// Read and parse version line
 	versionBytes, err := readLine(reader)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading version: %v\n", err)
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("reading version: %w", err)
 	}
 	if len(versionBytes) > 0 && versionBytes[len(versionBytes)-1] == lineSeparator {
 		versionBytes = versionBytes[:len(versionBytes)-1]
 	}
-	versionString := string(versionBytes)
-	fmt.Printf("Database version: %s\n", versionString)
 
 	// Read and parse config line
 	configBytes, err := readLine(reader)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("reading config: %w", err)
 	}
 	if len(configBytes) > 0 && configBytes[len(configBytes)-1] == lineSeparator {
 		configBytes = configBytes[:len(configBytes)-1]
@@ -104,117 +102,101 @@ func main() {
 
 	var metadata cacheMetadata
 	if err := json.Unmarshal(configBytes, &metadata); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing config JSON: %v\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Printf("Config:\n")
-	fmt.Printf("  WorkingDirectory: %s\n", metadata.Config.WorkingDirectory)
-	fmt.Printf("  RootDirs: %v\n", metadata.Config.RootDirs)
-	fmt.Printf("  FilesystemView: %s\n", metadata.Config.FilesystemView)
-	fmt.Printf("  FollowSymlinks: %v\n", metadata.Config.FollowSymlinks)
-	fmt.Printf("  ExcludeDirs: %v\n", metadata.Config.ExcludeDirs)
-	fmt.Printf("  PruneFiles: %v\n", metadata.Config.PruneFiles)
-	fmt.Printf("  IncludeFiles: %v\n", metadata.Config.IncludeFiles)
-	fmt.Printf("  IncludeSuffixes: %v\n", metadata.Config.IncludeSuffixes)
-	fmt.Println()
-
-	// Read and parse cache entries
-	allPaths := []string{}
-	entryCount := 0
-	fileCount := 0
-
-	processCacheEntry := func(cacheEntry CacheEntry) {
-		for _, persistedDirs := range cacheEntry {
-			for _, dir := range persistedDirs.Dirs {
-				path := joinCleanPaths(persistedDirs.Root, dir.P)
-				// Clean the path
-				path = filepath.Clean(path)
-				if !filepath.IsAbs(path) {
-					path = "/" + path
-				}
-
-				allPaths = append(allPaths, path)
-				entryCount++
-				fileCount += len(dir.F)
-
-				// Also add file paths if there are files in this directory
-				for _, filename := range dir.F {
-					filePath := joinCleanPaths(path, filename)
-					filePath = filepath.Clean(filePath)
-					if !filepath.IsAbs(filePath) {
-						filePath = "/" + filePath
-					}
-					allPaths = append(allPaths, filePath)
-				}
-			}
-		}
+		return nil, nil, fmt.Errorf("parsing config JSON: %w", err)
 	}
+	metadata.Version = string(versionBytes)
 
+	var entries []CacheEntry
 	for {
 		entryBytes, err := readLine(reader)
 		if err != nil {
 			if err == io.EOF {
-				// Process any remaining data
 				if len(entryBytes) > 0 {
-					// Process this last line without newline
 					var cacheEntry CacheEntry
 					if err := json.Unmarshal(entryBytes, &cacheEntry); err == nil {
-						processCacheEntry(cacheEntry)
+						entries = append(entries, cacheEntry)
 					}
 				}
 				break
 			}
-			fmt.Fprintf(os.Stderr, "Error reading cache entry: %v\n", err)
-			os.Exit(1)
-		}
-
-		if len(entryBytes) == 0 {
-			continue
+			return nil, nil, fmt.Errorf("reading cache entry: %w", err)
 		}
 
 		if len(entryBytes) > 0 && entryBytes[len(entryBytes)-1] == lineSeparator {
 			entryBytes = entryBytes[:len(entryBytes)-1]
 		}
-
 		if len(entryBytes) == 0 {
 			continue
 		}
 
 		var cacheEntry CacheEntry
 		if err := json.Unmarshal(entryBytes, &cacheEntry); err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing cache entry JSON: %v\n", err)
-			os.Exit(1)
+			return nil, nil, fmt.Errorf("parsing cache entry JSON: %w", err)
 		}
-
-		processCacheEntry(cacheEntry)
+		entries = append(entries, cacheEntry)
 	}
 
-	// Remove duplicates and sort
-	uniquePaths := make(map[string]bool)
-	for _, path := range allPaths {
-		uniquePaths[path] = true
+	return &metadata, entries, nil
+}
+
+func main() {
+	var dbPath string
+	var shellMode bool
+	var diffPath string
+	var diffJSON bool
+	var diffNameOnly bool
+	var format string
+	var bloomFPR float64
+	var verifyMode bool
+	flag.StringVar(&dbPath, "db", "", "path to database file (required)")
+	flag.BoolVar(&shellMode, "shell", false, "after loading the database, start an interactive query shell instead of dumping it")
+	flag.StringVar(&diffPath, "diff", "", "path to a second database file; report directories added, removed or modified relative to -db")
+	flag.BoolVar(&diffJSON, "diff-json", false, "emit -diff output as JSON instead of human-readable text")
+	flag.BoolVar(&diffNameOnly, "name-only", false, "with -diff, print only the changed paths")
+	flag.StringVar(&format, "format", "text", "output format: text, json, ndjson, protobuf, or bloom")
+	flag.Float64Var(&bloomFPR, "bloom-fpr", 0.01, "target false-positive rate for -format=bloom")
+	flag.BoolVar(&verifyMode, "verify", false, "re-stat every cached directory against the filesystem and report stale, missing, and new entries")
+	flag.Parse()
+
+	if dbPath == "" {
+		fmt.Fprintf(os.Stderr, "Error: -db flag is required\n")
+		flag.Usage()
+		os.Exit(1)
 	}
 
-	sortedPaths := make([]string, 0, len(uniquePaths))
-	for path := range uniquePaths {
-		sortedPaths = append(sortedPaths, path)
+	metadata, entries, err := loadDatabaseFile(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading database: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Sort paths
-	for i := 0; i < len(sortedPaths); i++ {
-		for j := i + 1; j < len(sortedPaths); j++ {
-			if sortedPaths[i] > sortedPaths[j] {
-				sortedPaths[i], sortedPaths[j] = sortedPaths[j], sortedPaths[i]
-			}
+	if diffPath != "" {
+		_, otherEntries, err := loadDatabaseFile(diffPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading diff database: %v\n", err)
+			os.Exit(1)
 		}
+		printDiff(diffDatabases(entries, otherEntries), diffJSON, diffNameOnly)
+		return
 	}
 
-	fmt.Printf("Database contains %d directory entries with %d files\n", entryCount, fileCount)
-	fmt.Printf("Total unique paths: %d\n\n", len(sortedPaths))
-	fmt.Println("All paths in database:")
+	if shellMode {
+		runShell(metadata, entries)
+		return
+	}
 
-	for _, path := range sortedPaths {
-		fmt.Println(path)
+	if verifyMode {
+		printVerifyReport(runVerify(metadata, entries))
+		return
+	}
+
+	exporter, err := newExporter(format, bloomFPR)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := exporter.Export(os.Stdout, metadata, entries); err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting database: %v\n", err)
+		os.Exit(1)
 	}
 }
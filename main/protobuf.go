@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// protobufExporter writes the database as a stream of length-delimited
+// protobuf messages, one per PersistedDirs group, using the same wire
+// format the real protobuf runtime would produce for these field numbers:
+//
+//	message PersistedDirInfo {
+//	  string path = 1;
+//	  sint64 mtime = 2;
+//	  uint64 inode = 3;
+//	  repeated string files = 4;
+//	}
+//	message PersistedDirs {
+//	  uint64 device = 1;
+//	  string root = 2;
+//	  repeated PersistedDirInfo dirs = 3;
+//	}
+//
+// There is no protobuf dependency in this tool, so the wire format is
+// produced by hand; this keeps the export self-contained while still being
+// readable by any standard protobuf decoder given the .proto above.
+type protobufExporter struct{}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func appendTag(buf *bytes.Buffer, fieldNum int, wireType int) {
+	appendVarint(buf, uint64(fieldNum)<<3|uint64(wireType))
+}
+
+func appendVarint(buf *bytes.Buffer, v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	buf.Write(tmp[:n])
+}
+
+func appendString(buf *bytes.Buffer, fieldNum int, s string) {
+	if s == "" {
+		return
+	}
+	appendTag(buf, fieldNum, wireBytes)
+	appendVarint(buf, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+func zigzag64(n int64) uint64 {
+	return uint64(n<<1) ^ uint64(n>>63)
+}
+
+func encodePersistedDirInfo(dir PersistedDirInfo) []byte {
+	var buf bytes.Buffer
+	appendString(&buf, 1, dir.P)
+	if dir.T != 0 {
+		appendTag(&buf, 2, wireVarint)
+		appendVarint(&buf, zigzag64(dir.T))
+	}
+	if dir.I != 0 {
+		appendTag(&buf, 3, wireVarint)
+		appendVarint(&buf, dir.I)
+	}
+	for _, f := range dir.F {
+		// Repeated elements are always written, even when empty.
+		appendTag(&buf, 4, wireBytes)
+		appendVarint(&buf, uint64(len(f)))
+		buf.WriteString(f)
+	}
+	return buf.Bytes()
+}
+
+func encodePersistedDirs(dirs PersistedDirs) []byte {
+	var buf bytes.Buffer
+	if dirs.Device != 0 {
+		appendTag(&buf, 1, wireVarint)
+		appendVarint(&buf, dirs.Device)
+	}
+	appendString(&buf, 2, dirs.Root)
+	for _, dir := range dirs.Dirs {
+		encoded := encodePersistedDirInfo(dir)
+		appendTag(&buf, 3, wireBytes)
+		appendVarint(&buf, uint64(len(encoded)))
+		buf.Write(encoded)
+	}
+	return buf.Bytes()
+}
+
+func (protobufExporter) Export(w io.Writer, metadata *cacheMetadata, entries []CacheEntry) error {
+	for _, cacheEntry := range entries {
+		for _, persistedDirs := range cacheEntry {
+			message := encodePersistedDirs(persistedDirs)
+
+			var lengthBuf bytes.Buffer
+			appendVarint(&lengthBuf, uint64(len(message)))
+			if _, err := w.Write(lengthBuf.Bytes()); err != nil {
+				return err
+			}
+			if _, err := w.Write(message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
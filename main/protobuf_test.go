@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// decodedField is one (field number, wire type, payload) tuple read back out
+// of a hand-encoded protobuf message, used to check encodePersistedDirInfo
+// and encodePersistedDirs without depending on a real protobuf library.
+type decodedField struct {
+	num      int
+	wireType int
+	varint   uint64
+	bytes    []byte
+}
+
+func decodeFields(t *testing.T, buf []byte) []decodedField {
+	t.Helper()
+	var fields []decodedField
+	for len(buf) > 0 {
+		tag, n := binary.Uvarint(buf)
+		if n <= 0 {
+			t.Fatalf("bad tag varint in %v", buf)
+		}
+		buf = buf[n:]
+		fieldNum := int(tag >> 3)
+		wireType := int(tag & 0x7)
+		switch wireType {
+		case wireVarint:
+			v, n := binary.Uvarint(buf)
+			if n <= 0 {
+				t.Fatalf("bad varint field in %v", buf)
+			}
+			buf = buf[n:]
+			fields = append(fields, decodedField{num: fieldNum, wireType: wireType, varint: v})
+		case wireBytes:
+			length, n := binary.Uvarint(buf)
+			if n <= 0 {
+				t.Fatalf("bad length varint in %v", buf)
+			}
+			buf = buf[n:]
+			if uint64(len(buf)) < length {
+				t.Fatalf("truncated length-delimited field: want %d bytes, have %d", length, len(buf))
+			}
+			fields = append(fields, decodedField{num: fieldNum, wireType: wireType, bytes: append([]byte(nil), buf[:length]...)})
+			buf = buf[length:]
+		default:
+			t.Fatalf("unexpected wire type %d", wireType)
+		}
+	}
+	return fields
+}
+
+func TestEncodePersistedDirInfo(t *testing.T) {
+	dir := PersistedDirInfo{P: "/a/b", T: -5, I: 42, F: []string{"x.bp", "y.bp"}}
+	fields := decodeFields(t, encodePersistedDirInfo(dir))
+
+	var gotPath string
+	var gotMTime int64
+	var gotInode uint64
+	var gotFiles []string
+	for _, f := range fields {
+		switch f.num {
+		case 1:
+			gotPath = string(f.bytes)
+		case 2:
+			gotMTime = int64(f.varint>>1) ^ -int64(f.varint&1) // un-zigzag
+		case 3:
+			gotInode = f.varint
+		case 4:
+			gotFiles = append(gotFiles, string(f.bytes))
+		}
+	}
+
+	if gotPath != dir.P {
+		t.Errorf("path = %q, want %q", gotPath, dir.P)
+	}
+	if gotMTime != dir.T {
+		t.Errorf("mtime = %d, want %d", gotMTime, dir.T)
+	}
+	if gotInode != dir.I {
+		t.Errorf("inode = %d, want %d", gotInode, dir.I)
+	}
+	if len(gotFiles) != 2 || gotFiles[0] != "x.bp" || gotFiles[1] != "y.bp" {
+		t.Errorf("files = %v, want [x.bp y.bp]", gotFiles)
+	}
+}
+
+func TestEncodePersistedDirInfoOmitsZeroFields(t *testing.T) {
+	// T and I are 0 (proto3 default), so the varint fields should be
+	// entirely absent from the wire, matching standard proto3 encoding.
+	fields := decodeFields(t, encodePersistedDirInfo(PersistedDirInfo{P: "/a"}))
+	for _, f := range fields {
+		if f.num == 2 || f.num == 3 {
+			t.Errorf("expected field %d to be omitted when its value is the zero value", f.num)
+		}
+	}
+}
+
+func TestEncodePersistedDirs(t *testing.T) {
+	dirs := PersistedDirs{
+		Device: 7,
+		Root:   "/root0",
+		Dirs: []PersistedDirInfo{
+			{P: "a", T: 1, I: 1},
+			{P: "b", T: 2, I: 2},
+		},
+	}
+	fields := decodeFields(t, encodePersistedDirs(dirs))
+
+	var gotDevice uint64
+	var gotRoot string
+	var nestedDirs int
+	for _, f := range fields {
+		switch f.num {
+		case 1:
+			gotDevice = f.varint
+		case 2:
+			gotRoot = string(f.bytes)
+		case 3:
+			nestedDirs++
+			nested := decodeFields(t, f.bytes)
+			if len(nested) == 0 {
+				t.Errorf("nested PersistedDirInfo decoded to no fields")
+			}
+		}
+	}
+
+	if gotDevice != dirs.Device {
+		t.Errorf("device = %d, want %d", gotDevice, dirs.Device)
+	}
+	if gotRoot != dirs.Root {
+		t.Errorf("root = %q, want %q", gotRoot, dirs.Root)
+	}
+	if nestedDirs != len(dirs.Dirs) {
+		t.Errorf("nested dir count = %d, want %d", nestedDirs, len(dirs.Dirs))
+	}
+}
+
+func TestProtobufExporterLengthDelimited(t *testing.T) {
+	entries := []CacheEntry{CacheEntry{{Root: "/root0", Dirs: []PersistedDirInfo{{P: "a", T: 1, I: 1}}}}}
+
+	var buf bytes.Buffer
+	if err := (protobufExporter{}).Export(&buf, &cacheMetadata{}, entries); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	data := buf.Bytes()
+	length, n := binary.Uvarint(data)
+	if n <= 0 {
+		t.Fatalf("bad leading length varint in %v", data)
+	}
+	message := data[n:]
+	if uint64(len(message)) != length {
+		t.Fatalf("length prefix says %d bytes, message is %d bytes", length, len(message))
+	}
+	if len(decodeFields(t, message)) == 0 {
+		t.Fatalf("length-delimited message decoded to no fields")
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dirNode is one directory in the in-memory index built from the database.
+// Children are kept sorted by name so lookups can binary-search a single
+// path component rather than scanning every entry in the database, which is
+// what the non-interactive dump mode does today.
+type dirNode struct {
+	name     string
+	info     *PersistedDirInfo
+	children []*dirNode
+}
+
+func (n *dirNode) child(name string) *dirNode {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].name >= name })
+	if i < len(n.children) && n.children[i].name == name {
+		return n.children[i]
+	}
+	return nil
+}
+
+func (n *dirNode) childOrCreate(name string) *dirNode {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].name >= name })
+	if i < len(n.children) && n.children[i].name == name {
+		return n.children[i]
+	}
+	child := &dirNode{name: name}
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = child
+	return child
+}
+
+// pathIndex is the queryable view of a database built once at shell startup.
+type pathIndex struct {
+	root  *dirNode
+	roots []string
+}
+
+func splitPath(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+// buildPathIndex walks every parsed cache entry exactly once and inserts its
+// directories into a tree keyed by path component, so later `ls`/`find`/
+// `stat` commands are O(log n) per component instead of re-scanning the
+// whole database.
+func buildPathIndex(entries []CacheEntry) *pathIndex {
+	idx := &pathIndex{root: &dirNode{}}
+	seenRoot := make(map[string]bool)
+
+	for _, cacheEntry := range entries {
+		for _, persistedDirs := range cacheEntry {
+			if !seenRoot[persistedDirs.Root] {
+				seenRoot[persistedDirs.Root] = true
+				idx.roots = append(idx.roots, persistedDirs.Root)
+			}
+			for i := range persistedDirs.Dirs {
+				dir := &persistedDirs.Dirs[i]
+				path := cleanAbsPath(persistedDirs.Root, dir.P)
+				node := idx.root
+				for _, component := range splitPath(path) {
+					node = node.childOrCreate(component)
+				}
+				node.info = dir
+			}
+		}
+	}
+
+	sort.Strings(idx.roots)
+	return idx
+}
+
+func (idx *pathIndex) lookup(path string) *dirNode {
+	node := idx.root
+	for _, component := range splitPath(path) {
+		node = node.child(component)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+func (idx *pathIndex) fullPath(components []string) string {
+	if len(components) == 0 {
+		return "/"
+	}
+	return "/" + strings.Join(components, "/")
+}
+
+// walk invokes fn with the full path of every indexed directory, depth first.
+func (idx *pathIndex) walk(fn func(path string, node *dirNode)) {
+	var visit func(node *dirNode, components []string)
+	visit = func(node *dirNode, components []string) {
+		if node.info != nil {
+			fn(idx.fullPath(components), node)
+		}
+		for _, child := range node.children {
+			visit(child, append(components, child.name))
+		}
+	}
+	visit(idx.root, nil)
+}
+
+// matchGlob reports whether path matches pattern, where pattern segments
+// follow filepath.Match semantics except that a "**" segment matches zero or
+// more path segments.
+func matchGlob(pattern, path string) bool {
+	return matchGlobSegments(splitPath(pattern), splitPath(path))
+}
+
+func matchGlobSegments(patSegs, pathSegs []string) bool {
+	if len(patSegs) == 0 {
+		return len(pathSegs) == 0
+	}
+	if patSegs[0] == "**" {
+		if matchGlobSegments(patSegs[1:], pathSegs) {
+			return true
+		}
+		if len(pathSegs) == 0 {
+			return false
+		}
+		return matchGlobSegments(patSegs, pathSegs[1:])
+	}
+	if len(pathSegs) == 0 {
+		return false
+	}
+	if ok, err := filepath.Match(patSegs[0], pathSegs[0]); err != nil || !ok {
+		return false
+	}
+	return matchGlobSegments(patSegs[1:], pathSegs[1:])
+}
+
+// runShell builds the path index once and then serves commands read from
+// stdin until EOF or "exit"/"quit".
+func runShell(metadata *cacheMetadata, entries []CacheEntry) {
+	idx := buildPathIndex(entries)
+
+	fmt.Printf("Loaded database version %s (%d roots)\n", metadata.Version, len(idx.roots))
+	fmt.Println("Commands: ls <dir>, find <glob>, stat <path>, count, roots, help, exit")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !scanner.Scan() {
+			fmt.Println()
+			return
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "exit", "quit":
+			return
+		case "help":
+			fmt.Println("Commands: ls <dir>, find <glob>, stat <path>, count, roots, help, exit")
+		case "roots":
+			for _, root := range idx.roots {
+				fmt.Println(root)
+			}
+		case "count":
+			dirCount, fileCount := 0, 0
+			idx.walk(func(path string, node *dirNode) {
+				dirCount++
+				fileCount += len(node.info.F)
+			})
+			fmt.Printf("%d directories, %d files\n", dirCount, fileCount)
+		case "ls":
+			if len(fields) != 2 {
+				fmt.Println("usage: ls <dir>")
+				continue
+			}
+			runLs(idx, fields[1])
+		case "find":
+			if len(fields) != 2 {
+				fmt.Println("usage: find <glob>")
+				continue
+			}
+			runFind(idx, fields[1])
+		case "stat":
+			if len(fields) != 2 {
+				fmt.Println("usage: stat <path>")
+				continue
+			}
+			runStat(idx, fields[1])
+		default:
+			fmt.Printf("unknown command: %s (try help)\n", fields[0])
+		}
+	}
+}
+
+func runLs(idx *pathIndex, dir string) {
+	node := idx.lookup(dir)
+	if node == nil {
+		fmt.Printf("not found: %s\n", dir)
+		return
+	}
+	for _, child := range node.children {
+		if child.info != nil {
+			fmt.Printf("%s/\n", child.name)
+		} else {
+			fmt.Printf("%s\n", child.name)
+		}
+	}
+	if node.info != nil {
+		for _, file := range node.info.F {
+			fmt.Println(file)
+		}
+	}
+}
+
+func runFind(idx *pathIndex, glob string) {
+	idx.walk(func(path string, node *dirNode) {
+		if matchGlob(glob, path) {
+			fmt.Println(path)
+		}
+		for _, file := range node.info.F {
+			filePath := cleanAbsPath(path, file)
+			if matchGlob(glob, filePath) {
+				fmt.Println(filePath)
+			}
+		}
+	})
+}
+
+func runStat(idx *pathIndex, path string) {
+	node := idx.lookup(path)
+	if node == nil || node.info == nil {
+		fmt.Printf("not a tracked directory: %s\n", path)
+		return
+	}
+	fmt.Printf("path:  %s\n", path)
+	fmt.Printf("mtime: %s (%d)\n", time.Unix(node.info.T, 0).UTC(), node.info.T)
+	fmt.Printf("inode: %d\n", node.info.I)
+	fmt.Printf("files: %d\n", len(node.info.F))
+}
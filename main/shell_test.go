@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/a/b", "/a/b", true},
+		{"/a/b", "/a/c", false},
+		{"/a/*", "/a/b", true},
+		{"/a/*", "/a/b/c", false},
+		{"/a/**", "/a/b/c/d", true},
+		{"/a/**", "/a", true},
+		{"/**/foo", "/a/b/foo", true},
+		{"/**/foo", "/foo", true},
+		{"/**/foo", "/a/b/bar", false},
+		{"/a/**/c", "/a/x/y/c", true},
+		{"/a/**/c", "/a/c", true},
+		{"/a/**/c", "/a/x/y/d", false},
+		{"/*.bp", "/build.bp", true},
+		{"/*.bp", "/sub/build.bp", false},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.pattern, c.path); got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import "sort"
+
+// trieNode is one path component in the trie built while collecting every
+// directory and file path out of a database. Children are kept sorted by
+// name, which both avoids an O(n^2) bubble sort over the flattened path
+// list and makes the natural DFS order of the trie sorted component by
+// component. That order differs from a plain byte-wise sort of the full
+// path strings where a name contains a byte below '/': "/r/a/x.bp" comes
+// before "/r/a-b" here.
+type trieNode struct {
+	name     string
+	children []*trieNode
+	isPath   bool
+}
+
+func (n *trieNode) childOrCreate(name string) *trieNode {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].name >= name })
+	if i < len(n.children) && n.children[i].name == name {
+		return n.children[i]
+	}
+	child := &trieNode{name: name}
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = child
+	return child
+}
+
+func (n *trieNode) insert(path string) {
+	node := n
+	for _, component := range splitPath(path) {
+		node = node.childOrCreate(component)
+	}
+	node.isPath = true
+}
+
+// walk visits every path stored in the trie in component-wise sorted order
+// (see trieNode), without ever
+// materializing the full path list: each component is appended to prefix
+// on the way down and trimmed back off on the way up.
+func (n *trieNode) walk(fn func(path string)) {
+	var visit func(node *trieNode, prefix string)
+	visit = func(node *trieNode, prefix string) {
+		if node.isPath {
+			if prefix == "" {
+				fn("/")
+			} else {
+				fn(prefix)
+			}
+		}
+		for _, child := range node.children {
+			visit(child, prefix+"/"+child.name)
+		}
+	}
+	visit(n, "")
+}
+
+// collectSortedPaths flattens every directory and file in entries into a
+// deduplicated list of absolute paths sorted component by component,
+// alongside the raw directory
+// and file counts seen while walking. Paths are collected into a trie as
+// each entry is processed and then emitted via an in-order DFS, so the cost
+// is proportional to the total number of path bytes rather than quadratic
+// in the number of paths.
+func collectSortedPaths(entries []CacheEntry) (sortedPaths []string, entryCount int, fileCount int) {
+	root := &trieNode{}
+
+	for _, cacheEntry := range entries {
+		for _, persistedDirs := range cacheEntry {
+			for _, dir := range persistedDirs.Dirs {
+				path := cleanAbsPath(persistedDirs.Root, dir.P)
+				root.insert(path)
+
+				entryCount++
+				fileCount += len(dir.F)
+
+				for _, filename := range dir.F {
+					root.insert(cleanAbsPath(path, filename))
+				}
+			}
+		}
+	}
+
+	root.walk(func(path string) {
+		sortedPaths = append(sortedPaths, path)
+	})
+
+	return sortedPaths, entryCount, fileCount
+}
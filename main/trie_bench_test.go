@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// syntheticEntries builds a CacheEntry set with numDirs directories spread
+// across a handful of roots, each containing a few files, to approximate
+// the shape of an Android-sized finder database.
+func syntheticEntries(numDirs int) []CacheEntry {
+	const rootsPerEntry = 4
+	const filesPerDir = 3
+
+	persistedDirs := make([]PersistedDirs, 0, rootsPerEntry)
+
+	for r := 0; r < rootsPerEntry; r++ {
+		persistedDirs = append(persistedDirs, PersistedDirs{
+			Device: uint64(r),
+			Root:   fmt.Sprintf("/root%d", r),
+		})
+	}
+
+	for i := 0; i < numDirs; i++ {
+		files := make([]string, filesPerDir)
+		for f := 0; f < filesPerDir; f++ {
+			files[f] = fmt.Sprintf("file%d.bp", f)
+		}
+		dir := PersistedDirInfo{
+			P: fmt.Sprintf("dir%d/sub%d", i/1000, i),
+			T: int64(i),
+			I: uint64(i),
+			F: files,
+		}
+		target := i % rootsPerEntry
+		persistedDirs[target].Dirs = append(persistedDirs[target].Dirs, dir)
+	}
+
+	return []CacheEntry{CacheEntry(persistedDirs)}
+}
+
+func BenchmarkCollectSortedPaths500k(b *testing.B) {
+	entries := syntheticEntries(500000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sortedPaths, _, _ := collectSortedPaths(entries)
+		if len(sortedPaths) == 0 {
+			b.Fatal("expected non-empty path list")
+		}
+	}
+}
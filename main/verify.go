@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+)
+
+// verifyReport is the result of re-statting every cached directory against
+// the live filesystem.
+type verifyReport struct {
+	Stale   []string // cached mtime/inode no longer matches the filesystem
+	Missing []string // cached directory no longer exists
+	New     []string // directory exists on disk but isn't in the database
+}
+
+func inodeOf(info fs.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return stat.Ino
+	}
+	return 0
+}
+
+func statPath(path string, followSymlinks bool) (fs.FileInfo, error) {
+	if followSymlinks {
+		return os.Stat(path)
+	}
+	return os.Lstat(path)
+}
+
+// runVerify re-stats every directory recorded in the database and reports
+// which ones are stale or missing, then walks the configured root
+// directories to report ones that exist on disk but were never cached.
+func runVerify(metadata *cacheMetadata, entries []CacheEntry) verifyReport {
+	idx := buildPathIndex(entries)
+	var report verifyReport
+
+	idx.walk(func(path string, node *dirNode) {
+		info, err := statPath(path, metadata.Config.FollowSymlinks)
+		if err != nil {
+			if os.IsNotExist(err) {
+				report.Missing = append(report.Missing, path)
+			} else {
+				fmt.Fprintf(os.Stderr, "warning: stat %s: %v\n", path, err)
+			}
+			return
+		}
+		if info.ModTime().Unix() != node.info.T || inodeOf(info) != node.info.I {
+			report.Stale = append(report.Stale, path)
+		}
+	})
+
+	roots := metadata.Config.RootDirs
+	if len(roots) == 0 {
+		roots = idx.roots
+	}
+	for _, root := range roots {
+		report.New = append(report.New, findUncachedDirs(root, idx, metadata.Config)...)
+	}
+
+	sort.Strings(report.Stale)
+	sort.Strings(report.Missing)
+	sort.Strings(report.New)
+	return report
+}
+
+// dirIdentity is the (device, inode) pair that identifies a directory on
+// disk regardless of which path was used to reach it, so a symlink cycle
+// can be recognized even though the path string itself never repeats.
+type dirIdentity struct {
+	dev, ino uint64
+}
+
+func identityOf(info fs.FileInfo) (dirIdentity, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return dirIdentity{}, false
+	}
+	return dirIdentity{dev: uint64(stat.Dev), ino: stat.Ino}, true
+}
+
+// findUncachedDirs walks root on disk and returns directories that are not
+// present in idx, honoring ExcludeDirs (subtrees to skip entirely) and
+// PruneFiles (a marker file whose presence stops recursion into that
+// directory, mirroring the finder's own invalidation rules). When
+// config.FollowSymlinks is set, symlinks to directories are traversed just
+// like real directories, matching the Stale/Missing pass's use of
+// statPath; a visited-identity guard (keyed on the target's device/inode,
+// not its path string) stops a symlink cycle from recursing forever.
+func findUncachedDirs(root string, idx *pathIndex, config cacheConfig) []string {
+	excluded := make(map[string]bool, len(config.ExcludeDirs))
+	for _, dir := range config.ExcludeDirs {
+		excluded[dir] = true
+	}
+	pruneFiles := make(map[string]bool, len(config.PruneFiles))
+	for _, name := range config.PruneFiles {
+		pruneFiles[name] = true
+	}
+
+	var uncached []string
+	visited := make(map[dirIdentity]bool)
+
+	var walk func(path string)
+	walk = func(path string) {
+		if node := idx.lookup(path); node == nil || node.info == nil {
+			uncached = append(uncached, path)
+		}
+
+		if info, err := os.Stat(path); err == nil {
+			if id, ok := identityOf(info); ok {
+				if visited[id] {
+					return // already recursed into this directory via another path; stop here to avoid looping on a symlink cycle
+				}
+				visited[id] = true
+			}
+		}
+		if dirHasPruneMarker(path, pruneFiles) {
+			return
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return // best effort; report what we can still stat
+		}
+		for _, entry := range entries {
+			if excluded[entry.Name()] {
+				continue
+			}
+			childPath := filepath.Join(path, entry.Name())
+			isDir := entry.IsDir()
+			if !isDir && config.FollowSymlinks && entry.Type()&os.ModeSymlink != 0 {
+				if info, err := os.Stat(childPath); err == nil && info.IsDir() {
+					isDir = true
+				}
+			}
+			if isDir {
+				walk(childPath)
+			}
+		}
+	}
+	walk(root)
+	return uncached
+}
+
+func dirHasPruneMarker(dir string, pruneFiles map[string]bool) bool {
+	if len(pruneFiles) == 0 {
+		return false
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && pruneFiles[entry.Name()] {
+			return true
+		}
+	}
+	return false
+}
+
+func printVerifyReport(report verifyReport) {
+	fmt.Printf("Stale (%d):\n", len(report.Stale))
+	for _, path := range report.Stale {
+		fmt.Printf("  %s\n", path)
+	}
+	fmt.Printf("Missing (%d):\n", len(report.Missing))
+	for _, path := range report.Missing {
+		fmt.Printf("  %s\n", path)
+	}
+	fmt.Printf("New (%d):\n", len(report.New))
+	for _, path := range report.New {
+		fmt.Printf("  %s\n", path)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFindUncachedDirsSymlinkCycle reproduces a symlink pointing back at one
+// of its own ancestors with FollowSymlinks enabled. Without identity-based
+// cycle detection this recurses until the kernel's own ELOOP limit kicks in,
+// emitting dozens of bogus "New" paths along the way.
+func TestFindUncachedDirsSymlinkCycle(t *testing.T) {
+	root := t.TempDir()
+	real := filepath.Join(root, "real")
+	if err := os.Mkdir(real, 0755); err != nil {
+		t.Fatal(err)
+	}
+	loopLink := filepath.Join(real, "loop")
+	if err := os.Symlink(real, loopLink); err != nil {
+		t.Fatal(err)
+	}
+
+	idx := buildPathIndex(nil)
+	config := cacheConfig{FollowSymlinks: true}
+
+	got := findUncachedDirs(root, idx, config)
+
+	want := map[string]bool{root: true, real: true, loopLink: true}
+	if len(got) != len(want) {
+		t.Fatalf("expected exactly %d uncached dirs (no symlink cycle recursion), got %d: %v", len(want), len(got), got)
+	}
+	for _, p := range got {
+		if !want[p] {
+			t.Errorf("unexpected uncached dir %s (symlink cycle was not stopped)", p)
+		}
+	}
+}